@@ -0,0 +1,124 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package group
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/harbor/src/common/utils/ldap"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// MaxGroupDepth bounds how many memberOf hops GetNestedGroupDNs will follow.
+var MaxGroupDepth = 10
+
+// nestedGroupCacheTTL is how long a resolved transitive group set is cached.
+const nestedGroupCacheTTL = 5 * time.Minute
+
+type nestedGroupCacheEntry struct {
+	dns      []string
+	cachedAt time.Time
+}
+
+var (
+	nestedGroupCacheMu sync.Mutex
+	nestedGroupCache   = map[string]nestedGroupCacheEntry{}
+)
+
+// searchGroupMemberOf is the LDAP memberOf lookup GetNestedGroupDNs walks.
+// It is a var so tests can substitute a fake directory.
+var searchGroupMemberOf = ldap.SearchGroupMemberOf
+
+// GetNestedGroupDNs expands directDNs into the transitive closure of LDAP
+// groups reachable by following memberOf chains, up to MaxGroupDepth hops.
+// A visited set guards against cycles. Results are cached per distinct set
+// of directDNs for nestedGroupCacheTTL.
+func GetNestedGroupDNs(directDNs []string) []string {
+	if len(directDNs) == 0 {
+		return directDNs
+	}
+
+	key := groupCacheKey(directDNs)
+	if cached, ok := lookupNestedGroupCache(key); ok {
+		return cached
+	}
+
+	visited := map[string]bool{}
+	for _, dn := range directDNs {
+		visited[dn] = true
+	}
+
+	queue := append([]string{}, directDNs...)
+	for depth := 0; depth < MaxGroupDepth && len(queue) > 0; depth++ {
+		var next []string
+		for _, dn := range queue {
+			parents, err := searchGroupMemberOf(dn)
+			if err != nil {
+				log.Errorf("failed to resolve memberOf for group %s: %v", dn, err)
+				continue
+			}
+			for _, parent := range parents {
+				if visited[parent] {
+					continue
+				}
+				visited[parent] = true
+				next = append(next, parent)
+			}
+		}
+		queue = next
+	}
+
+	result := make([]string, 0, len(visited))
+	for dn := range visited {
+		result = append(result, dn)
+	}
+	sort.Strings(result)
+
+	storeNestedGroupCache(key, result)
+	return result
+}
+
+func groupCacheKey(dns []string) string {
+	sorted := append([]string{}, dns...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\x00")
+}
+
+func lookupNestedGroupCache(key string) ([]string, bool) {
+	nestedGroupCacheMu.Lock()
+	defer nestedGroupCacheMu.Unlock()
+	entry, ok := nestedGroupCache[key]
+	if !ok || time.Since(entry.cachedAt) > nestedGroupCacheTTL {
+		return nil, false
+	}
+	return entry.dns, true
+}
+
+func storeNestedGroupCache(key string, dns []string) {
+	nestedGroupCacheMu.Lock()
+	defer nestedGroupCacheMu.Unlock()
+	nestedGroupCache[key] = nestedGroupCacheEntry{dns: dns, cachedAt: time.Now()}
+}
+
+// resetNestedGroupCache clears the cache. Used by tests so each case starts
+// from a clean slate regardless of what earlier cases resolved.
+func resetNestedGroupCache() {
+	nestedGroupCacheMu.Lock()
+	defer nestedGroupCacheMu.Unlock()
+	nestedGroupCache = map[string]nestedGroupCacheEntry{}
+}
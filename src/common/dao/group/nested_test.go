@@ -0,0 +1,85 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package group
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func withFakeDirectory(t *testing.T, parentsOf map[string][]string) {
+	t.Helper()
+	origSearch := searchGroupMemberOf
+	origDepth := MaxGroupDepth
+	searchGroupMemberOf = func(dn string) ([]string, error) {
+		return parentsOf[dn], nil
+	}
+	resetNestedGroupCache()
+	t.Cleanup(func() {
+		searchGroupMemberOf = origSearch
+		MaxGroupDepth = origDepth
+		resetNestedGroupCache()
+	})
+}
+
+func TestGetNestedGroupDNsExpandsChain(t *testing.T) {
+	withFakeDirectory(t, map[string][]string{
+		"cn=dev":       {"cn=eng"},
+		"cn=eng":       {"cn=all-staff"},
+		"cn=all-staff": nil,
+	})
+
+	got := GetNestedGroupDNs([]string{"cn=dev"})
+	want := []string{"cn=all-staff", "cn=dev", "cn=eng"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetNestedGroupDNs() = %v, want %v", got, want)
+	}
+}
+
+func TestGetNestedGroupDNsDetectsCycle(t *testing.T) {
+	withFakeDirectory(t, map[string][]string{
+		"cn=a": {"cn=b"},
+		"cn=b": {"cn=a"},
+	})
+
+	got := GetNestedGroupDNs([]string{"cn=a"})
+	want := []string{"cn=a", "cn=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetNestedGroupDNs() with a cycle = %v, want %v (should terminate, not loop forever)", got, want)
+	}
+}
+
+func TestGetNestedGroupDNsRespectsMaxDepth(t *testing.T) {
+	// cn=0 -> cn=1 -> cn=2 -> ... -> cn=20, an unbounded chain.
+	chain := map[string][]string{}
+	for i := 0; i < 20; i++ {
+		chain[fmt.Sprintf("cn=%d", i)] = []string{fmt.Sprintf("cn=%d", i+1)}
+	}
+	withFakeDirectory(t, chain)
+	MaxGroupDepth = 3
+
+	got := GetNestedGroupDNs([]string{"cn=0"})
+	want := []string{"cn=0", "cn=1", "cn=2", "cn=3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetNestedGroupDNs() with MaxGroupDepth=3 = %v, want %v", got, want)
+	}
+}
+
+func TestGetNestedGroupDNsEmptyInput(t *testing.T) {
+	if got := GetNestedGroupDNs(nil); len(got) != 0 {
+		t.Fatalf("GetNestedGroupDNs(nil) = %v, want empty", got)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"github.com/astaxie/beego/orm"
+
+	"github.com/vmware/harbor/src/common"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/security/rbaccache"
+)
+
+// legacyRoleCodeToBuiltInRole maps the historical project-member role codes
+// to the built-in role IDs seeded into role_permissions at install time, so
+// that projects created before the RBAC migration keep working unchanged.
+var legacyRoleCodeToBuiltInRole = map[string]int{
+	"MDRWS": common.RoleProjectAdmin,
+	"RWS":   common.RoleDeveloper,
+	"RS":    common.RoleGuest,
+}
+
+// GetRolePermissions returns the permissions granted to a role by its seeded
+// or custom role_permissions rows.
+func GetRolePermissions(roleID int) ([]models.Permission, error) {
+	o := orm.NewOrm()
+	var rows []*models.RolePermission
+	_, err := o.QueryTable(&models.RolePermission{}).
+		Filter("RoleID", roleID).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+	perms := make([]models.Permission, 0, len(rows))
+	for _, row := range rows {
+		perms = append(perms, models.Permission{
+			Verb:         models.Verb(row.Verb),
+			ResourceType: models.ResourceType(row.ResourceType),
+		})
+	}
+	return perms, nil
+}
+
+// resolveLegacyRoleCode maps a legacy MDRWS/RWS/RS project-member role code
+// to its seeded built-in role ID. It is split out from
+// GetProjectMemberRoleIDs so the mapping itself can be unit tested without a
+// database.
+func resolveLegacyRoleCode(roleCode string) (int, bool) {
+	roleID, ok := legacyRoleCodeToBuiltInRole[roleCode]
+	return roleID, ok
+}
+
+// GetProjectMemberRoleIDs returns the role IDs a user holds in a project. A
+// member assigned a custom role via AssignRoleToMember returns that role
+// alone; otherwise the legacy MDRWS/RWS/RS code on the project member
+// binding is resolved to its seeded built-in role.
+func GetProjectMemberRoleIDs(userID, projectID int) ([]int, error) {
+	customRoleID, err := GetCustomRoleID(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if customRoleID != 0 {
+		return []int{customRoleID}, nil
+	}
+
+	roleList, err := GetUserProjectRoles(userID, projectID, common.UserMember)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := make([]int, 0, len(roleList))
+	for _, role := range roleList {
+		if roleID, ok := resolveLegacyRoleCode(role.RoleCode); ok {
+			roleIDs = append(roleIDs, roleID)
+		}
+	}
+	return roleIDs, nil
+}
+
+// GetCustomRoleID returns the custom RBAC role directly bound to a project
+// member via the project_member.role_id column, or 0 if the member was
+// never assigned one and should fall back to its legacy MDRWS/RWS/RS code.
+func GetCustomRoleID(userID, projectID int) (int, error) {
+	o := orm.NewOrm()
+	var roleID int
+	err := o.Raw(`SELECT role_id FROM project_member
+		WHERE user_id = ? AND project_id = ? AND role_id IS NOT NULL`,
+		userID, projectID).QueryRow(&roleID)
+	if err == orm.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return roleID, nil
+}
+
+// CreateRole persists a custom role definition and its permissions so it can
+// later be assigned to project members with AssignRoleToMember.
+func CreateRole(role *models.Role) (int, error) {
+	o := orm.NewOrm()
+	roleID, err := o.Insert(&models.Role{Name: role.Name})
+	if err != nil {
+		return 0, err
+	}
+	for _, perm := range role.Permissions {
+		if _, err := o.Insert(&models.RolePermission{
+			RoleID:       int(roleID),
+			Verb:         string(perm.Verb),
+			ResourceType: string(perm.ResourceType),
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return int(roleID), nil
+}
+
+// GetRole returns a custom role and its permissions by ID.
+func GetRole(roleID int) (*models.Role, error) {
+	o := orm.NewOrm()
+	role := &models.Role{RoleID: roleID}
+	if err := o.Read(role); err != nil {
+		return nil, err
+	}
+	perms, err := GetRolePermissions(roleID)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = perms
+	return role, nil
+}
+
+// AssignRoleToMember binds a custom role to a project member, taking
+// precedence over that member's legacy MDRWS/RWS/RS role code. The member's
+// cached role resolution is invalidated so the new binding takes effect on
+// their very next permission check.
+func AssignRoleToMember(userID, projectID, roleID int) error {
+	o := orm.NewOrm()
+	_, err := o.Raw(`UPDATE project_member SET role_id = ?
+		WHERE user_id = ? AND project_id = ?`,
+		roleID, userID, projectID).Exec()
+	if err != nil {
+		return err
+	}
+	rbaccache.InvalidateUser(userID)
+	return nil
+}
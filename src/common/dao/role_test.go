@@ -0,0 +1,42 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common"
+)
+
+func TestResolveLegacyRoleCode(t *testing.T) {
+	cases := []struct {
+		code   string
+		wantID int
+		wantOK bool
+	}{
+		{"MDRWS", common.RoleProjectAdmin, true},
+		{"RWS", common.RoleDeveloper, true},
+		{"RS", common.RoleGuest, true},
+		{"bogus", 0, false},
+		{"", 0, false},
+	}
+	for _, c := range cases {
+		roleID, ok := resolveLegacyRoleCode(c.code)
+		if ok != c.wantOK || (ok && roleID != c.wantID) {
+			t.Errorf("resolveLegacyRoleCode(%q) = (%d, %v), want (%d, %v)",
+				c.code, roleID, ok, c.wantID, c.wantOK)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// AccessAudit is a persisted record of a single permission decision made by
+// a security.Context, written to the access_audit table.
+type AccessAudit struct {
+	ID           int64     `orm:"pk;auto;column(id)" json:"id"`
+	RequestID    string    `orm:"column(request_id)" json:"request_id"`
+	RemoteAddr   string    `orm:"column(remote_addr)" json:"remote_addr"`
+	Username     string    `orm:"column(username)" json:"username"`
+	ProjectID    int64     `orm:"column(project_id)" json:"project_id"`
+	Verb         string    `orm:"column(verb)" json:"verb"`
+	Resource     string    `orm:"column(resource)" json:"resource"`
+	Granted      bool      `orm:"column(granted)" json:"granted"`
+	Role         string    `orm:"column(role)" json:"role"`
+	CreationTime time.Time `orm:"column(creation_time)" json:"creation_time"`
+}
+
+// TableName ...
+func (a *AccessAudit) TableName() string {
+	return "access_audit"
+}
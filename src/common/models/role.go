@@ -0,0 +1,71 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+// Verb is an action that can be granted against a resource type, e.g. pull
+// or push.
+type Verb string
+
+// Supported verbs for RBAC permissions.
+const (
+	VerbPull           Verb = "pull"
+	VerbPush           Verb = "push"
+	VerbDelete         Verb = "delete"
+	VerbScan           Verb = "scan"
+	VerbSign           Verb = "sign"
+	VerbManageMembers  Verb = "manage-members"
+	VerbManageWebhooks Verb = "manage-webhooks"
+)
+
+// ResourceType is the kind of object a permission applies to.
+type ResourceType string
+
+// Supported resource types for RBAC permissions.
+const (
+	ResourceProject      ResourceType = "project"
+	ResourceRepository   ResourceType = "repository"
+	ResourceTag          ResourceType = "tag"
+	ResourceHelmChart    ResourceType = "helm-chart"
+	ResourceWebhook      ResourceType = "webhook"
+	ResourceRobotAccount ResourceType = "robot-account"
+)
+
+// Permission grants a verb against a resource type.
+type Permission struct {
+	Verb         Verb         `json:"verb"`
+	ResourceType ResourceType `json:"resource_type"`
+}
+
+// Role is a named, pluggable set of permissions that can be bound to a
+// project member in place of the legacy MDRWS/RWS/RS role codes.
+type Role struct {
+	RoleID      int          `orm:"pk;auto;column(id)" json:"role_id"`
+	Name        string       `orm:"column(name)" json:"name"`
+	Permissions []Permission `orm:"-" json:"permissions"`
+}
+
+// RolePermission is a single row of the role_permissions table: one row per
+// (role, verb, resource type) tuple granted to a role.
+type RolePermission struct {
+	ID           int    `orm:"pk;auto;column(id)" json:"id"`
+	RoleID       int    `orm:"column(role_id)" json:"role_id"`
+	Verb         string `orm:"column(verb)" json:"verb"`
+	ResourceType string `orm:"column(resource_type)" json:"resource_type"`
+}
+
+// TableName ...
+func (r *RolePermission) TableName() string {
+	return "role_permissions"
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"context"
+	"time"
+)
+
+// contextKey keeps this package's context values from colliding with keys
+// set by other packages.
+type contextKey int
+
+const (
+	requestIDContextKey contextKey = iota
+	remoteAddrContextKey
+)
+
+// NewContextWithRequestID returns a context carrying requestID.
+func NewContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stashed by
+// NewContextWithRequestID, or "" if none is present.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// NewContextWithRemoteAddr returns a context carrying remoteAddr.
+func NewContextWithRemoteAddr(ctx context.Context, remoteAddr string) context.Context {
+	return context.WithValue(ctx, remoteAddrContextKey, remoteAddr)
+}
+
+// RemoteAddrFromContext returns the remote address stashed by
+// NewContextWithRemoteAddr, or "" if none is present.
+func RemoteAddrFromContext(ctx context.Context) string {
+	addr, _ := ctx.Value(remoteAddrContextKey).(string)
+	return addr
+}
+
+// AuditEvent is a structured record of a single permission decision made by
+// a Context implementation.
+type AuditEvent struct {
+	RequestID  string
+	RemoteAddr string
+	Subject    string
+	ProjectID  int64
+	Verb       string
+	Resource   string
+	Granted    bool
+	Role       string
+	Timestamp  time.Time
+}
+
+// AuditSink receives an AuditEvent for every permission decision a Context
+// makes. Implementations must be safe for concurrent use.
+type AuditSink interface {
+	Record(ctx context.Context, event AuditEvent)
+}
+
+// NoopAuditSink discards every event. It is the default sink.
+type NoopAuditSink struct{}
+
+// Record implements AuditSink.
+func (NoopAuditSink) Record(ctx context.Context, event AuditEvent) {}
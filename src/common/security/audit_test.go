@@ -0,0 +1,40 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestIDFromContext(t *testing.T) {
+	if got := RequestIDFromContext(context.Background()); got != "" {
+		t.Fatalf("RequestIDFromContext(no value) = %q, want empty", got)
+	}
+	ctx := NewContextWithRequestID(context.Background(), "req-1")
+	if got := RequestIDFromContext(ctx); got != "req-1" {
+		t.Fatalf("RequestIDFromContext() = %q, want %q", got, "req-1")
+	}
+}
+
+func TestRemoteAddrFromContext(t *testing.T) {
+	if got := RemoteAddrFromContext(context.Background()); got != "" {
+		t.Fatalf("RemoteAddrFromContext(no value) = %q, want empty", got)
+	}
+	ctx := NewContextWithRemoteAddr(context.Background(), "10.0.0.1:443")
+	if got := RemoteAddrFromContext(ctx); got != "10.0.0.1:443" {
+		t.Fatalf("RemoteAddrFromContext() = %q, want %q", got, "10.0.0.1:443")
+	}
+}
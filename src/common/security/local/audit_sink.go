@@ -0,0 +1,50 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/security"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// DBAuditSink persists every permission decision to the access_audit table.
+type DBAuditSink struct{}
+
+// NewDBAuditSink returns an AuditSink backed by the access_audit table.
+func NewDBAuditSink() *DBAuditSink {
+	return &DBAuditSink{}
+}
+
+// Record implements security.AuditSink.
+func (s *DBAuditSink) Record(ctx context.Context, event security.AuditEvent) {
+	record := &models.AccessAudit{
+		RequestID:    event.RequestID,
+		RemoteAddr:   event.RemoteAddr,
+		Username:     event.Subject,
+		ProjectID:    event.ProjectID,
+		Verb:         event.Verb,
+		Resource:     event.Resource,
+		Granted:      event.Granted,
+		Role:         event.Role,
+		CreationTime: event.Timestamp,
+	}
+	if err := dao.AddAccessAudit(record); err != nil {
+		log.Errorf("failed to write access audit record for %s: %v", event.Subject, err)
+	}
+}
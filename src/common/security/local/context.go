@@ -15,26 +15,102 @@
 package local
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/vmware/harbor/src/common"
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/dao/group"
 	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/security"
+	"github.com/vmware/harbor/src/common/security/rbaccache"
 	"github.com/vmware/harbor/src/common/utils/log"
 	"github.com/vmware/harbor/src/ui/promgr"
 )
 
 // SecurityContext implements security.Context interface based on database
 type SecurityContext struct {
-	user *models.User
-	pm   promgr.ProjectManager
+	user      *models.User
+	pm        promgr.ProjectManager
+	auditSink security.AuditSink
+	// roleCache memoizes GetProjectRoles within the lifetime of this
+	// SecurityContext, so the handful of permission checks a single API
+	// request makes against the same project only resolve roles once.
+	roleCache map[string][]int
+	// projectCache memoizes resolveProject within the lifetime of this
+	// SecurityContext, so audit and role resolution share one pm.Get
+	// instead of each looking the project up on its own.
+	projectCache map[string]*models.Project
 }
 
 // NewSecurityContext ...
 func NewSecurityContext(user *models.User, pm promgr.ProjectManager) *SecurityContext {
 	return &SecurityContext{
-		user: user,
-		pm:   pm,
+		user:      user,
+		pm:        pm,
+		auditSink: security.NoopAuditSink{},
+	}
+}
+
+// WithAuditSink returns a copy of the SecurityContext that records every
+// permission decision to sink instead of discarding it.
+func (s *SecurityContext) WithAuditSink(sink security.AuditSink) *SecurityContext {
+	if sink == nil {
+		sink = security.NoopAuditSink{}
+	}
+	cp := *s
+	cp.auditSink = sink
+	return &cp
+}
+
+// resolveProject memoizes s.pm.Get within the lifetime of this
+// SecurityContext, so the project lookup a permission check already did
+// can be reused by audit instead of being repeated.
+func (s *SecurityContext) resolveProject(projectIDOrName interface{}) (*models.Project, error) {
+	key := fmt.Sprintf("%v", projectIDOrName)
+	if s.projectCache == nil {
+		s.projectCache = map[string]*models.Project{}
+	}
+	if project, ok := s.projectCache[key]; ok {
+		return project, nil
+	}
+	project, err := s.pm.Get(projectIDOrName)
+	if err != nil {
+		return nil, err
 	}
+	s.projectCache[key] = project
+	return project, nil
+}
+
+// audit emits an AuditEvent for a single permission decision, tagging it
+// with the request ID and remote address carried on ctx. It is a no-op
+// when the SecurityContext has no AuditSink configured, so the default
+// case pays no extra project lookup on top of the permission check itself.
+// When a real sink is configured, the project lookup goes through
+// resolveProject so it reuses whatever the permission check already
+// resolved instead of repeating it.
+func (s *SecurityContext) audit(ctx context.Context, projectIDOrName interface{}, verb, resource string, granted bool, role string) {
+	if _, ok := s.auditSink.(security.NoopAuditSink); ok {
+		return
+	}
+
+	var projectID int64
+	if project, err := s.resolveProject(projectIDOrName); err == nil && project != nil {
+		projectID = project.ProjectID
+	}
+	s.auditSink.Record(ctx, security.AuditEvent{
+		RequestID:  security.RequestIDFromContext(ctx),
+		RemoteAddr: security.RemoteAddrFromContext(ctx),
+		Subject:    s.GetUsername(),
+		ProjectID:  projectID,
+		Verb:       verb,
+		Resource:   resource,
+		Granted:    granted,
+		Role:       role,
+		Timestamp:  time.Now(),
+	})
 }
 
 // IsAuthenticated returns true if the user has been authenticated
@@ -66,69 +142,105 @@ func (s *SecurityContext) IsSolutionUser() bool {
 }
 
 // HasReadPerm returns whether the user has read permission to the project
-func (s *SecurityContext) HasReadPerm(projectIDOrName interface{}) bool {
+func (s *SecurityContext) HasReadPerm(ctx context.Context, projectIDOrName interface{}) bool {
+	granted, role := s.hasReadPerm(projectIDOrName)
+	s.audit(ctx, projectIDOrName, "read", "project", granted, role)
+	return granted
+}
+
+func (s *SecurityContext) hasReadPerm(projectIDOrName interface{}) (bool, string) {
 	// public project
 	public, err := s.pm.IsPublic(projectIDOrName)
 	if err != nil {
 		log.Errorf("failed to check the public of project %v: %v",
 			projectIDOrName, err)
-		return false
+		return false, ""
 	}
 	if public {
-		return true
+		return true, "public"
 	}
 
 	// private project
 	if !s.IsAuthenticated() {
-		return false
+		return false, ""
 	}
 
 	// system admin
 	if s.IsSysAdmin() {
-		return true
+		return true, "sysadmin"
 	}
 
 	roles := s.GetProjectRoles(projectIDOrName)
-	return len(roles) > 0
+	return len(roles) > 0, roleNames(roles)
 }
 
 // HasWritePerm returns whether the user has write permission to the project
-func (s *SecurityContext) HasWritePerm(projectIDOrName interface{}) bool {
+func (s *SecurityContext) HasWritePerm(ctx context.Context, projectIDOrName interface{}) bool {
+	granted, role := s.hasWritePerm(projectIDOrName)
+	s.audit(ctx, projectIDOrName, "write", "project", granted, role)
+	return granted
+}
+
+func (s *SecurityContext) hasWritePerm(projectIDOrName interface{}) (bool, string) {
 	if !s.IsAuthenticated() {
-		return false
+		return false, ""
 	}
 	// system admin
 	if s.IsSysAdmin() {
-		return true
+		return true, "sysadmin"
 	}
 	roles := s.GetProjectRoles(projectIDOrName)
 	for _, role := range roles {
 		switch role {
 		case common.RoleProjectAdmin,
 			common.RoleDeveloper:
-			return true
+			return true, roleNames(roles)
 		}
 	}
-	return false
+	return false, ""
 }
 
 // HasAllPerm returns whether the user has all permissions to the project
-func (s *SecurityContext) HasAllPerm(projectIDOrName interface{}) bool {
+func (s *SecurityContext) HasAllPerm(ctx context.Context, projectIDOrName interface{}) bool {
+	granted, role := s.hasAllPerm(projectIDOrName)
+	s.audit(ctx, projectIDOrName, "all", "project", granted, role)
+	return granted
+}
+
+func (s *SecurityContext) hasAllPerm(projectIDOrName interface{}) (bool, string) {
 	if !s.IsAuthenticated() {
-		return false
+		return false, ""
 	}
 	// system admin
 	if s.IsSysAdmin() {
-		return true
+		return true, "sysadmin"
 	}
 	roles := s.GetProjectRoles(projectIDOrName)
 	for _, role := range roles {
 		switch role {
 		case common.RoleProjectAdmin:
-			return true
+			return true, roleNames(roles)
 		}
 	}
-	return false
+	return false, ""
+}
+
+// roleNames renders role IDs as a comma separated string for audit events.
+func roleNames(roles []int) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		switch role {
+		case common.RoleProjectAdmin:
+			names[i] = "ProjectAdmin"
+		case common.RoleDeveloper:
+			names[i] = "Developer"
+		case common.RoleGuest:
+			names[i] = "Guest"
+		default:
+			names[i] = fmt.Sprintf("role-%d", role)
+		}
+	}
+	return strings.Join(names, ",")
 }
 
 // GetProjectRoles ...
@@ -137,6 +249,27 @@ func (s *SecurityContext) GetProjectRoles(projectIDOrName interface{}) []int {
 		return []int{}
 	}
 
+	cacheKey := rbaccache.Key(s.user.UserID, projectIDOrName)
+	if s.roleCache == nil {
+		s.roleCache = map[string][]int{}
+	}
+	if roles, ok := s.roleCache[cacheKey]; ok {
+		return roles
+	}
+	if roles, ok := rbaccache.Lookup(cacheKey); ok {
+		s.roleCache[cacheKey] = roles
+		return roles
+	}
+
+	roles := s.resolveProjectRoles(projectIDOrName)
+	s.roleCache[cacheKey] = roles
+	rbaccache.Store(cacheKey, roles)
+	return roles
+}
+
+// resolveProjectRoles performs the DB (and, for group members, LDAP) lookup
+// GetProjectRoles memoizes.
+func (s *SecurityContext) resolveProjectRoles(projectIDOrName interface{}) []int {
 	roles := []int{}
 	user, err := dao.GetUser(models.User{
 		Username: s.GetUsername(),
@@ -149,7 +282,7 @@ func (s *SecurityContext) GetProjectRoles(projectIDOrName interface{}) []int {
 		log.Debugf("user %s not found", s.GetUsername())
 		return roles
 	}
-	project, err := s.pm.Get(projectIDOrName)
+	project, err := s.resolveProject(projectIDOrName)
 	if err != nil {
 		log.Errorf("failed to get project %v: %v", projectIDOrName, err)
 		return roles
@@ -158,20 +291,10 @@ func (s *SecurityContext) GetProjectRoles(projectIDOrName interface{}) []int {
 		log.Errorf("project %v not found", projectIDOrName)
 		return roles
 	}
-	roleList, err := dao.GetUserProjectRoles(user.UserID, project.ProjectID, common.UserMember)
+	roles, err = dao.GetProjectMemberRoleIDs(user.UserID, project.ProjectID)
 	if err != nil {
 		log.Errorf("failed to get roles of user %d to project %d: %v", user.UserID, project.ProjectID, err)
-		return roles
-	}
-	for _, role := range roleList {
-		switch role.RoleCode {
-		case "MDRWS":
-			roles = append(roles, common.RoleProjectAdmin)
-		case "RWS":
-			roles = append(roles, common.RoleDeveloper)
-		case "RS":
-			roles = append(roles, common.RoleGuest)
-		}
+		return []int{}
 	}
 	if len(roles) != 0 {
 		return roles
@@ -179,17 +302,60 @@ func (s *SecurityContext) GetProjectRoles(projectIDOrName interface{}) []int {
 	return s.GetRolesByGroup(projectIDOrName)
 }
 
+// Can returns whether the user has been granted verb on resourceType in the
+// project, resolved against the role_permissions rows of every role the
+// user holds there. System admins are always granted.
+func (s *SecurityContext) Can(ctx context.Context, verb models.Verb, resourceType models.ResourceType, projectIDOrName interface{}) bool {
+	granted, role := s.can(verb, resourceType, projectIDOrName)
+	s.audit(ctx, projectIDOrName, string(verb), string(resourceType), granted, role)
+	return granted
+}
+
+func (s *SecurityContext) can(verb models.Verb, resourceType models.ResourceType, projectIDOrName interface{}) (bool, string) {
+	if !s.IsAuthenticated() {
+		return false, ""
+	}
+	if s.IsSysAdmin() {
+		return true, "sysadmin"
+	}
+	roles := s.GetProjectRoles(projectIDOrName)
+	for _, roleID := range roles {
+		perms, err := dao.GetRolePermissions(roleID)
+		if err != nil {
+			log.Errorf("failed to get permissions of role %d: %v", roleID, err)
+			continue
+		}
+		if permissionsGrant(perms, verb, resourceType) {
+			return true, roleNames(roles)
+		}
+	}
+	return false, ""
+}
+
+// permissionsGrant reports whether perms contains a permission matching
+// verb and resourceType.
+func permissionsGrant(perms []models.Permission, verb models.Verb, resourceType models.ResourceType) bool {
+	for _, perm := range perms {
+		if perm.Verb == verb && perm.ResourceType == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
 // GetRolesByGroup - Get the group role of current user to the project
 func (s *SecurityContext) GetRolesByGroup(projectIDOrName interface{}) []int {
 	var roles []int
 	user := s.user
-	project, err := s.pm.Get(projectIDOrName)
+	project, err := s.resolveProject(projectIDOrName)
 	//No user, group or project info
 	if err != nil || project == nil || user == nil || len(user.GroupList) == 0 {
 		return roles
 	}
-	//Get role by LDAP group
-	groupDNConditions := group.GetGroupDNQueryCondition(user.GroupList)
+	//Get role by LDAP group, expanding nested group memberships so a role
+	//bound to a parent group is inherited by members of its subgroups.
+	nestedDNs := group.GetNestedGroupDNs(user.GroupList)
+	groupDNConditions := group.GetGroupDNQueryCondition(nestedDNs)
 	roles, err = dao.GetRolesByLDAPGroup(project.ProjectID, groupDNConditions)
 	if err != nil {
 		return nil
@@ -0,0 +1,56 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestPermissionsGrant(t *testing.T) {
+	perms := []models.Permission{
+		{Verb: models.VerbPull, ResourceType: models.ResourceRepository},
+		{Verb: models.VerbScan, ResourceType: models.ResourceRepository},
+	}
+
+	cases := []struct {
+		verb         models.Verb
+		resourceType models.ResourceType
+		want         bool
+	}{
+		{models.VerbPull, models.ResourceRepository, true},
+		{models.VerbScan, models.ResourceRepository, true},
+		{models.VerbPush, models.ResourceRepository, false},
+		{models.VerbPull, models.ResourceTag, false},
+	}
+	for _, c := range cases {
+		if got := permissionsGrant(perms, c.verb, c.resourceType); got != c.want {
+			t.Errorf("permissionsGrant(%v, %v) = %v, want %v", c.verb, c.resourceType, got, c.want)
+		}
+	}
+
+	if permissionsGrant(nil, models.VerbPull, models.ResourceRepository) {
+		t.Error("permissionsGrant with no permissions should return false")
+	}
+}
+
+func TestRoleNames(t *testing.T) {
+	got := roleNames([]int{1, 2, 3, 99})
+	want := "ProjectAdmin,Developer,Guest,role-99"
+	if got != want {
+		t.Errorf("roleNames() = %q, want %q", got, want)
+	}
+}
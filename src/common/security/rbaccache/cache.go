@@ -0,0 +1,79 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rbaccache is the process-wide cache of resolved project role
+// sets, shared by security/local and dao so that dao can invalidate entries
+// without importing security/local.
+package rbaccache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TTL bounds how long a resolved project role set is cached.
+const TTL = 5 * time.Minute
+
+type entry struct {
+	roles    []int
+	cachedAt time.Time
+}
+
+var (
+	mu    sync.RWMutex
+	cache = map[string]entry{}
+)
+
+// Key identifies a user's resolved roles in a project.
+func Key(userID int, projectIDOrName interface{}) string {
+	return fmt.Sprintf("%d/%v", userID, projectIDOrName)
+}
+
+// Lookup returns the cached roles for key, if present and not expired.
+func Lookup(key string) ([]int, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := cache[key]
+	if !ok || time.Since(e.cachedAt) > TTL {
+		return nil, false
+	}
+	return e.roles, true
+}
+
+// Store caches roles under key for TTL.
+func Store(key string, roles []int) {
+	mu.Lock()
+	defer mu.Unlock()
+	cache[key] = entry{roles: roles, cachedAt: time.Now()}
+}
+
+// InvalidateUser drops every cached role resolution for userID.
+func InvalidateUser(userID int) {
+	prefix := fmt.Sprintf("%d/", userID)
+	mu.Lock()
+	defer mu.Unlock()
+	for key := range cache {
+		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+			delete(cache, key)
+		}
+	}
+}
+
+// InvalidateAll drops the entire cache.
+func InvalidateAll() {
+	mu.Lock()
+	defer mu.Unlock()
+	cache = map[string]entry{}
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbaccache
+
+import "testing"
+
+// dockerPullChecks is how many permission checks a single `docker pull`
+// against one project triggers against HasReadPerm/HasWritePerm/Can: a
+// manifest HEAD, a manifest GET, a blob HEAD and a tag list.
+const dockerPullChecks = 4
+
+// simulateCachedLookup mirrors what SecurityContext.GetProjectRoles does on
+// every check: look in the cache first, and only call resolve (standing in
+// for the dao.GetUser/pm.Get/dao.GetProjectMemberRoleIDs round trip) on a
+// miss.
+func simulateCachedLookup(key string, resolve func() []int) []int {
+	if roles, ok := Lookup(key); ok {
+		return roles
+	}
+	roles := resolve()
+	Store(key, roles)
+	return roles
+}
+
+func TestCacheCollapsesRepeatedLookupsWithinAPull(t *testing.T) {
+	defer InvalidateAll()
+	key := Key(7, 42)
+
+	queries := 0
+	resolve := func() []int {
+		queries++
+		return []int{1}
+	}
+
+	for i := 0; i < dockerPullChecks; i++ {
+		roles := simulateCachedLookup(key, resolve)
+		if len(roles) != 1 || roles[0] != 1 {
+			t.Fatalf("simulateCachedLookup() = %v, want [1]", roles)
+		}
+	}
+
+	if queries != 1 {
+		t.Fatalf("resolve was called %d times for %d checks in the same pull, want 1", queries, dockerPullChecks)
+	}
+}
+
+func TestInvalidateUserForcesReResolve(t *testing.T) {
+	defer InvalidateAll()
+	key := Key(7, 42)
+
+	queries := 0
+	resolve := func() []int {
+		queries++
+		return []int{1}
+	}
+
+	simulateCachedLookup(key, resolve)
+	InvalidateUser(7)
+	simulateCachedLookup(key, resolve)
+
+	if queries != 2 {
+		t.Fatalf("resolve was called %d times across an invalidation, want 2", queries)
+	}
+}
+
+// BenchmarkDockerPullSequence reports the DB-query cost of a dockerPullChecks
+// permission-check sequence with the cache in place: one resolve per
+// project per pull instead of one per check.
+func BenchmarkDockerPullSequence(b *testing.B) {
+	defer InvalidateAll()
+	queries := 0
+	resolve := func() []int {
+		queries++
+		return []int{1}
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		key := Key(7, i) // distinct project per iteration so every b.N forces one resolve
+		for c := 0; c < dockerPullChecks; c++ {
+			simulateCachedLookup(key, resolve)
+		}
+	}
+	b.ReportMetric(float64(queries)/float64(b.N), "queries/pull")
+	b.ReportMetric(float64(dockerPullChecks), "checks/pull")
+}
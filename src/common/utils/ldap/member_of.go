@@ -0,0 +1,70 @@
+// Copyright 2018 The Harbor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	goldap "gopkg.in/ldap.v2"
+
+	"github.com/vmware/harbor/src/common/config"
+)
+
+// SearchGroupMemberOf returns the DNs of the LDAP groups that groupDN is a
+// direct member of, read off the group entry's own memberOf attribute. It
+// is the query path dao/group.GetNestedGroupDNs walks, one hop at a time,
+// to resolve nested/hierarchical group membership.
+func SearchGroupMemberOf(groupDN string) ([]string, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	req := goldap.NewSearchRequest(
+		groupDN,
+		goldap.ScopeBaseObject,
+		goldap.NeverDerefAliases,
+		0, 0, false,
+		"(objectClass=*)",
+		[]string{"memberOf"},
+		nil,
+	)
+	result, err := conn.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var parents []string
+	for _, entry := range result.Entries {
+		parents = append(parents, entry.GetAttributeValues("memberOf")...)
+	}
+	return parents, nil
+}
+
+// dial opens a bound connection to the configured LDAP server.
+func dial() (*goldap.Conn, error) {
+	cfg, err := config.LDAPConf()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := goldap.Dial("tcp", cfg.LDAPURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Bind(cfg.LDAPSearchDn, cfg.LDAPSearchPassword); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}